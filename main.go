@@ -1,217 +1,221 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	"fmt"
-	"io/ioutil"
 	"log"
-	"net/http"
-	"net/http/cookiejar"
+	"net/smtp"
 	"os"
 	"strings"
-	"time"
+	"text/template"
 
 	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/aws/aws-sdk-go-v2/aws/external"
-	"github.com/aws/aws-sdk-go-v2/service/sns"
-)
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 
-var (
-	searchString = "Sökningen gav inga träffar"
-	snsSubject   = "Nytt förråd!"
-	snsMessage   = "Det verkar finnas ett nytt förråd tillgängligt!\n\nGå till https://www.stockholmshem.se/mina-sidor/smaforrad/ för att kontrollera"
-	headers      = map[string]string{
-		"User-Agent":   "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/84.0.4147.89 Safari/537.36",
-		"Accept":       "*/*",
-		"Content-Type": "application/x-www-form-urlencoded",
-	}
+	"github.com/nuttmeister/sthlmshem-sok-forrad/pkg/config"
+	"github.com/nuttmeister/sthlmshem-sok-forrad/pkg/notify"
+	"github.com/nuttmeister/sthlmshem-sok-forrad/pkg/scraper"
 )
 
-func main() {
-	lambda.Start(handler)
+// Provider carries everything handler needs, constructed once in main and
+// threaded through on every invocation. Clients holds one scraper per configured account.
+type Provider struct {
+	Clients  []*scraper.Client
+	Notifier notify.Notifier
+	Config   *config.Config
+	Logger   *log.Logger
 }
 
-func handler(ctx context.Context) error {
-	// Create the http client.
-	client, err := createHTTPClient(10000)
+func main() {
+	cfg, err := config.Load(os.Args[1:])
 	if err != nil {
-		return err
+		log.Fatal(err)
 	}
 
-	// Login against stockholmshem.
-	if err := login(client, headers); err != nil {
-		return err
-	}
-
-	// Check if there are any förråd.
-	new, err := forrad(client, headers)
+	awsCfg, err := awsconfig.LoadDefaultConfig(context.Background())
 	if err != nil {
-		return err
+		log.Fatal(err)
 	}
 
-	// Either send a message about new förråd or just return nil.
-	return send(ctx, new)
-}
-
-// createHTTPClient will create an new http client with a cookie jar with timeout in milliseconds.
-// Returns *http.Client and error.
-func createHTTPClient(timeout int) (*http.Client, error) {
-	jar, err := cookiejar.New(nil)
-	if err != nil {
-		return nil, fmt.Errorf("couldn't create cookie jar. %s", err.Error())
+	accounts := cfg.Accounts
+	if len(accounts) == 0 {
+		accounts = []config.Credentials{cfg.Credentials}
 	}
 
-	return &http.Client{
-		Jar:     jar,
-		Timeout: time.Millisecond * time.Duration(timeout),
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
-		},
-	}, nil
-}
-
-// crateHTTPRequest will create a request using method, url and payload and set headers based on headers.
-// Returns *http.Request and error.
-func createHTTPRequest(method string, url string, payload []byte, headers map[string]string) (*http.Request, error) {
-	// Replace any {epoch} with now.unix * 1000.
-	now := time.Now().Unix() * 1000
-	url = strings.ReplaceAll(url, "{epoch}", fmt.Sprintf("%d", now))
+	clients := make([]*scraper.Client, 0, len(accounts))
+	for _, account := range accounts {
+		client, err := scraper.New(cfg, account, awsCfg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		clients = append(clients, client)
+	}
 
-	req, err := http.NewRequest(method, url, bytes.NewReader(payload))
+	notifier, err := buildNotifier(cfg, awsCfg)
 	if err != nil {
-		return nil, fmt.Errorf("couldn't create request for %s %s. %s", method, url, err.Error())
+		log.Fatal(err)
 	}
 
-	// Set headers.
-	for key, val := range headers {
-		req.Header.Set(key, val)
+	p := &Provider{
+		Clients:  clients,
+		Notifier: notifier,
+		Config:   cfg,
+		Logger:   log.New(os.Stderr, "", log.LstdFlags),
 	}
 
-	return req, nil
+	lambda.Start(func(ctx context.Context) error {
+		return handler(ctx, p)
+	})
 }
 
-// sendHTTPRequest will send req using client and save any cookies
-// to the clients jar and return the body. If the response status code
-// doesn't match statusCode an error will be returned instead.
-// Returns []byte and error.
-func sendHTTPRequest(client *http.Client, req *http.Request, statusCode int) ([]byte, error) {
-	resp, err := client.Do(req)
+func handler(ctx context.Context, p *Provider) error {
+	tmpl, err := template.New("message").Parse(p.Config.Notify.MessageTemplate)
 	if err != nil {
-		return nil, fmt.Errorf("couldn't send http request. %s", err.Error())
+		return fmt.Errorf("couldn't parse notify.message_template. %s", err.Error())
 	}
-	defer resp.Body.Close()
 
-	// Read the body.
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("couldn't read body of response. %s", err.Error())
-	}
+	var errs []string
+	for _, client := range p.Clients {
+		new, err := checkAccount(ctx, client)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
 
-	// Check that statuscode matches what we expect.
-	// But also return the body.
-	if resp.StatusCode != statusCode {
-		return body, fmt.Errorf("status code missmatch. wanted %d got %d for %s", statusCode, resp.StatusCode, resp.Request.URL.String())
-	}
+		if len(new) == 0 {
+			continue
+		}
 
-	// Save all cookies if response is successfull.
-	client.Jar.SetCookies(resp.Request.URL, resp.Cookies())
-	return body, nil
-}
+		p.Logger.Printf("%d new förråd detected!\n", len(new))
 
-// login will login against stockholms hem.
-// Returns error.
-func login(client *http.Client, headers map[string]string) error {
-	// Create payload.
-	payload, err := createLoginPayload()
-	if err != nil {
-		return err
-	}
+		var body strings.Builder
+		if err := tmpl.Execute(&body, new); err != nil {
+			errs = append(errs, fmt.Errorf("couldn't render notify.message_template. %s", err.Error()).Error())
+			continue
+		}
 
-	// Create the request.
-	req, err := createHTTPRequest("POST", "https://www.stockholmshem.se/logga-in/?returnUrl=/mina-sidor/smaforrad/", payload, headers)
-	if err != nil {
-		return err
+		event := notify.Event{Title: p.Config.Notify.Subject, Body: body.String(), Forrad: toNotifyForrad(new)}
+		if err := p.Notifier.Notify(ctx, event); err != nil {
+			errs = append(errs, err.Error())
+		}
 	}
 
-	// Send the request.
-	_, err = sendHTTPRequest(client, req, 302)
-	if err != nil {
-		return err
+	if len(errs) > 0 {
+		return fmt.Errorf("couldn't check %d of %d accounts: %s", len(errs), len(p.Clients), strings.Join(errs, "; "))
 	}
 
 	return nil
 }
 
-// CreateLoginPayload returns payload that can be used for login or error
-// if it can't be created.
-// Returns []byte and error.
-func createLoginPayload() ([]byte, error) {
-	user, ok := os.LookupEnv("PERSONNR")
-	if !ok {
-		return nil, fmt.Errorf("couldn't get PERSONNR from environment")
+// toNotifyForrad converts scraper listings into their notify package equivalent.
+func toNotifyForrad(listings []scraper.Forrad) []notify.Forrad {
+	out := make([]notify.Forrad, 0, len(listings))
+	for _, l := range listings {
+		out = append(out, notify.Forrad{Address: l.Address, SizeM2: l.SizeM2, Rent: l.Rent, URL: l.URL})
 	}
-	pass, ok := os.LookupEnv("PASSWORD")
-	if !ok {
-		return nil, fmt.Errorf("couldn't get PASSWORD from environment")
-	}
-
-	return []byte(fmt.Sprintf("Username=%s&Password=%s", user, pass)), nil
+	return out
 }
 
-// forrad will check if there are any förråd avaible. Returns true if this is the case.
-// Returns bool and error.
-func forrad(client *http.Client, headers map[string]string) (bool, error) {
-	// Create the request.
-	req, err := createHTTPRequest("GET", "https://www.stockholmshem.se/widgets/?callback=jQuery17105048823634686723_{epoch}&widgets%5B%5D=alert&widgets%5B%5D=objektlista%40forrad&_={epoch}", nil, headers)
-	if err != nil {
-		return false, err
+// buildNotifier builds a Notifier from cfg.Notify.Backends, fanning out to all of
+// them concurrently if more than one is configured.
+// Returns notify.Notifier and error.
+func buildNotifier(cfg *config.Config, awsCfg aws.Config) (notify.Notifier, error) {
+	backends := cfg.Notify.Backends
+	if len(backends) == 0 {
+		backends = []string{"sns"}
 	}
 
-	// Send the request.
-	body, err := sendHTTPRequest(client, req, 200)
-	if err != nil {
-		return false, err
+	notifiers := make([]notify.Notifier, 0, len(backends))
+	for _, backend := range backends {
+		n, err := buildBackend(backend, cfg, awsCfg)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, n)
 	}
 
-	// Search string.
-	return !strings.Contains(string(body), searchString), nil
+	if len(notifiers) == 1 {
+		return notifiers[0], nil
+	}
+
+	return notify.NewFanout(notifiers...), nil
 }
 
-// send will send a message to the configured sns topic if there is a new förråd.
-// Returns error.
-func send(ctx context.Context, new bool) error {
-	// If there are no new just return nil.
-	if !new {
-		return nil
+// buildBackend builds a single notify backend by name.
+// Returns notify.Notifier and error.
+func buildBackend(backend string, cfg *config.Config, awsCfg aws.Config) (notify.Notifier, error) {
+	switch backend {
+	case "sns":
+		return notify.NewSNS(awsCfg, cfg.Notify.SNSTopicARN), nil
+
+	case "slack":
+		if cfg.Notify.Slack.WebhookURL == "" {
+			return nil, fmt.Errorf("notify.slack.webhook_url must be set to use the slack backend")
+		}
+		return notify.NewSlack(cfg.Notify.Slack.WebhookURL), nil
+
+	case "telegram":
+		if cfg.Notify.Telegram.BotToken == "" || cfg.Notify.Telegram.ChatID == "" {
+			return nil, fmt.Errorf("notify.telegram.bot_token and notify.telegram.chat_id must be set to use the telegram backend")
+		}
+		return notify.NewTelegram(cfg.Notify.Telegram.BotToken, cfg.Notify.Telegram.ChatID), nil
+
+	case "smtp":
+		s := cfg.Notify.SMTP
+		if s.Addr == "" || s.From == "" || len(s.To) == 0 {
+			return nil, fmt.Errorf("notify.smtp.addr, notify.smtp.from and notify.smtp.to must be set to use the smtp backend")
+		}
+
+		var auth smtp.Auth
+		if s.Username != "" {
+			auth = smtp.PlainAuth("", s.Username, s.Password, strings.Split(s.Addr, ":")[0])
+		}
+		return notify.NewSMTP(s.Addr, auth, s.From, s.To), nil
+
+	default:
+		return nil, fmt.Errorf("unknown notify backend %q", backend)
 	}
+}
 
-	// Simple log that we there are new förråd.
-	log.Printf("New förråd detected!\n")
+// checkAccount restores client's session (logging in fresh if needed), fetches the
+// currently listed förråd and persists the (possibly refreshed) session, together with
+// the updated seen set, for the next invocation.
+// Returns the listings that haven't been notified about before.
+// Returns []scraper.Forrad and error.
+func checkAccount(ctx context.Context, client *scraper.Client) ([]scraper.Forrad, error) {
+	restored, err := client.Restore(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	topic, ok := os.LookupEnv("TOPIC")
-	if !ok {
-		return fmt.Errorf("couldn't get TOPIC from environment")
+	// If we don't have a restored session, or it turns out to no longer be valid, log in fresh.
+	loggedIn := restored
+	var listings []scraper.Forrad
+	if loggedIn {
+		listings, err = client.Forrad()
+		if err == scraper.ErrNotLoggedIn {
+			loggedIn = false
+		} else if err != nil {
+			return nil, err
+		}
 	}
+	if !loggedIn {
+		if err := client.Login(); err != nil {
+			return nil, err
+		}
 
-	// Configure AWS config.
-	cfg, err := external.LoadDefaultAWSConfig()
-	if err != nil {
-		return fmt.Errorf("couldn't load AWS config. %s", err.Error())
+		listings, err = client.Forrad()
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	// Configure SNS.
-	svc := sns.New(cfg)
+	new := client.NewListings(listings)
 
-	// Send the message.
-	_, err = svc.PublishRequest(&sns.PublishInput{
-		Subject:  &snsSubject,
-		Message:  &snsMessage,
-		TopicArn: &topic,
-	}).Send(ctx)
-	if err != nil {
-		return fmt.Errorf("couldn't publish to sns. %s", err.Error())
+	if err := client.Persist(ctx); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return new, nil
 }
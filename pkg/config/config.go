@@ -0,0 +1,228 @@
+// Package config holds the application's configuration and how it's loaded.
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/parsers/toml"
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/knadh/koanf/providers/env"
+	"github.com/knadh/koanf/providers/file"
+	"github.com/spf13/pflag"
+)
+
+// delim is the key path delimiter used throughout the koanf tree, e.g. "scraper.min_size_m2".
+const delim = "."
+
+// Credentials are the personnummer/password pair used to log in to stockholmshem.se.
+type Credentials struct {
+	PersonNR string `koanf:"personnr"`
+	Password string `koanf:"password"`
+}
+
+// Scraper configures how the scraper talks to stockholmshem.se, and which parsed
+// förråd listings are worth notifying about.
+type Scraper struct {
+	Widgets   string `koanf:"widgets"`
+	UserAgent string `koanf:"user_agent"`
+	TimeoutMS int    `koanf:"timeout_ms"`
+
+	// MinSizeM2 and MaxRent drop listings outside that range if set above zero.
+	MinSizeM2 float64 `koanf:"min_size_m2"`
+	MaxRent   int     `koanf:"max_rent"`
+
+	// AreaRegex, if set, drops listings whose address doesn't match it.
+	AreaRegex string `koanf:"area_regex"`
+}
+
+// Notify configures where and how notifications about new förråd are sent.
+type Notify struct {
+	// Backends lists which notifier(s) to fan the notification out to, e.g. ["sns", "slack"].
+	Backends []string `koanf:"backends"`
+
+	SNSTopicARN string `koanf:"sns_topic_arn"`
+	Subject     string `koanf:"subject"`
+
+	// MessageTemplate is a text/template executed with the new []scraper.Forrad
+	// listings as its data to produce the notification body.
+	MessageTemplate string `koanf:"message_template"`
+
+	Slack    SlackNotify    `koanf:"slack"`
+	Telegram TelegramNotify `koanf:"telegram"`
+	SMTP     SMTPNotify     `koanf:"smtp"`
+}
+
+// SlackNotify configures the Slack notify backend.
+type SlackNotify struct {
+	WebhookURL string `koanf:"webhook_url"`
+}
+
+// TelegramNotify configures the Telegram notify backend.
+type TelegramNotify struct {
+	BotToken string `koanf:"bot_token"`
+	ChatID   string `koanf:"chat_id"`
+}
+
+// SMTPNotify configures the SMTP notify backend.
+type SMTPNotify struct {
+	Addr     string   `koanf:"addr"`
+	Username string   `koanf:"username"`
+	Password string   `koanf:"password"`
+	From     string   `koanf:"from"`
+	To       []string `koanf:"to"`
+}
+
+// SessionStore configures how (and if) an account's authenticated session is
+// persisted between invocations. It's env-only for now, see Load.
+//
+// EncryptionKeyHex is a raw 256 bit key read straight from SESSION_ENCRYPTION_KEY,
+// not a KMS-wrapped one: KMS envelope encryption would need the generated data key
+// stored alongside every session token so it can be decrypted again later, changing
+// the on-disk session format for what's otherwise a small, single-tenant scraper.
+// The env var is deliberately the simpler option; revisit if this ever needs key
+// rotation or an audit trail.
+type SessionStore struct {
+	Kind             string
+	Bucket           string
+	Key              string
+	Table            string
+	EncryptionKeyHex string
+}
+
+// Config holds everything the application needs to run. A single deployment can
+// watch several stockholmshem accounts in parallel via Accounts.
+type Config struct {
+	Credentials Credentials   `koanf:"credentials"`
+	Accounts    []Credentials `koanf:"accounts"`
+
+	Scraper Scraper `koanf:"scraper"`
+	Notify  Notify  `koanf:"notify"`
+
+	SessionStore SessionStore
+}
+
+// defaults seed the koanf tree before any file, environment or flag is loaded.
+var defaults = map[string]interface{}{
+	"scraper.widgets":    "https://www.stockholmshem.se/widgets/?callback=jQuery17105048823634686723_{epoch}&widgets%5B%5D=alert&widgets%5B%5D=objektlista%40forrad&_={epoch}",
+	"scraper.user_agent": "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/84.0.4147.89 Safari/537.36",
+	"scraper.timeout_ms": 10000,
+	"notify.backends":    []string{"sns"},
+	"notify.subject":     "Nytt förråd!",
+	"notify.message_template": "Det verkar finnas {{len .}} nytt/nya förråd tillgängligt/tillgängliga!\n\n" +
+		"{{range .}}- {{.Address}} ({{.SizeM2}} m², {{.Rent}} kr/mån): {{.URL}}\n{{end}}\n" +
+		"Gå till https://www.stockholmshem.se/mina-sidor/smaforrad/ för att kontrollera",
+}
+
+// legacyEnv maps the original, flat environment variables to their koanf key so
+// existing deployments keep working untouched.
+var legacyEnv = map[string]string{
+	"PERSONNR": "credentials.personnr",
+	"PASSWORD": "credentials.password",
+	"TOPIC":    "notify.sns_topic_arn",
+}
+
+// Load builds a Config by layering, in increasing order of precedence: built in
+// defaults, an optional TOML/YAML file (path via --conf or CONFIG_PATH), and
+// environment variables (including the legacy PERSONNR/PASSWORD/TOPIC names).
+// args is typically os.Args[1:].
+// Returns *Config and error.
+func Load(args []string) (*Config, error) {
+	k := koanf.New(delim)
+
+	if err := k.Load(confmap.Provider(defaults, delim), nil); err != nil {
+		return nil, fmt.Errorf("couldn't load default config. %s", err.Error())
+	}
+
+	flags := pflag.NewFlagSet("sthlmshem-sok-forrad", pflag.ContinueOnError)
+	confPath := flags.String("conf", "", "path to a TOML or YAML config file")
+	if err := flags.Parse(args); err != nil {
+		return nil, fmt.Errorf("couldn't parse flags. %s", err.Error())
+	}
+
+	path := *confPath
+	if path == "" {
+		path = os.Getenv("CONFIG_PATH")
+	}
+	if path != "" {
+		if err := k.Load(file.Provider(path), parserFor(path)); err != nil {
+			return nil, fmt.Errorf("couldn't load config file %s. %s", path, err.Error())
+		}
+	}
+
+	if err := k.Load(env.Provider("", delim, envKey), nil); err != nil {
+		return nil, fmt.Errorf("couldn't load config from environment. %s", err.Error())
+	}
+
+	cfg := &Config{}
+	if err := k.Unmarshal("", cfg); err != nil {
+		return nil, fmt.Errorf("couldn't unmarshal config. %s", err.Error())
+	}
+
+	if len(cfg.Accounts) == 0 {
+		if cfg.Credentials.PersonNR == "" || cfg.Credentials.Password == "" {
+			return nil, fmt.Errorf("credentials.personnr and credentials.password must be set")
+		}
+	} else {
+		for i, account := range cfg.Accounts {
+			if account.PersonNR == "" || account.Password == "" {
+				return nil, fmt.Errorf("accounts[%d].personnr and accounts[%d].password must be set", i, i)
+			}
+		}
+	}
+
+	if contains(cfg.Notify.Backends, "sns") && cfg.Notify.SNSTopicARN == "" {
+		return nil, fmt.Errorf("notify.sns_topic_arn must be set when notify.backends includes sns")
+	}
+
+	cfg.SessionStore = SessionStore{
+		Kind:             os.Getenv("SESSION_STORE"),
+		Bucket:           os.Getenv("SESSION_STORE_BUCKET"),
+		Key:              os.Getenv("SESSION_STORE_KEY"),
+		Table:            os.Getenv("SESSION_STORE_TABLE"),
+		EncryptionKeyHex: os.Getenv("SESSION_ENCRYPTION_KEY"),
+	}
+
+	return cfg, nil
+}
+
+// parserFor picks a koanf parser based on path's extension, defaulting to TOML.
+func parserFor(path string) koanf.Parser {
+	if strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml") {
+		return yaml.Parser()
+	}
+	return toml.Parser()
+}
+
+// contains reports whether s is present in list.
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// envKey maps an environment variable name to its koanf key. Legacy names
+// (PERSONNR, PASSWORD, TOPIC) map straight to their new home; anything else
+// prefixed with a known section (e.g. SCRAPER_MIN_SIZE_M2) is lowercased
+// and the underscore after the section name turned into delim
+// (scraper.min_size_m2). Keys nested below a subsection, such as
+// notify.slack.webhook_url, can't be set this way. Anything unrecognized is skipped.
+func envKey(s string) string {
+	if key, ok := legacyEnv[s]; ok {
+		return key
+	}
+
+	for _, prefix := range []string{"CREDENTIALS_", "SCRAPER_", "NOTIFY_", "ACCOUNTS_"} {
+		if strings.HasPrefix(s, prefix) {
+			return strings.Replace(strings.ToLower(s), "_", delim, 1)
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadPrecedenceFileThenEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	toml := `
+[credentials]
+personnr = "file-personnr"
+password = "file-password"
+
+[notify]
+sns_topic_arn = "arn:aws:sns:eu-north-1:123456789012:file-topic"
+`
+	if err := ioutil.WriteFile(path, []byte(toml), 0o600); err != nil {
+		t.Fatalf("couldn't write test config file. %s", err)
+	}
+
+	// Environment variables take precedence over the file.
+	t.Setenv("PASSWORD", "env-password")
+
+	cfg, err := Load([]string{"--conf", path})
+	if err != nil {
+		t.Fatalf("Load: %s", err)
+	}
+
+	if cfg.Credentials.PersonNR != "file-personnr" {
+		t.Errorf("Credentials.PersonNR = %q, want the value from the file", cfg.Credentials.PersonNR)
+	}
+	if cfg.Credentials.Password != "env-password" {
+		t.Errorf("Credentials.Password = %q, want the env override", cfg.Credentials.Password)
+	}
+	if cfg.Scraper.TimeoutMS != 10000 {
+		t.Errorf("Scraper.TimeoutMS = %d, want the default of 10000", cfg.Scraper.TimeoutMS)
+	}
+}
+
+func TestLoadRequiresCredentialsWithoutAccounts(t *testing.T) {
+	if _, err := Load(nil); err == nil {
+		t.Fatal("Load didn't require credentials.personnr/password when no accounts are configured")
+	}
+}
+
+func TestLoadAllowsCredentialsViaAccountsOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	toml := `
+[[accounts]]
+personnr = "acct-1"
+password = "acct-1-pass"
+
+[notify]
+backends = ["slack"]
+
+[notify.slack]
+webhook_url = "https://hooks.slack.example/x"
+`
+	if err := ioutil.WriteFile(path, []byte(toml), 0o600); err != nil {
+		t.Fatalf("couldn't write test config file. %s", err)
+	}
+
+	cfg, err := Load([]string{"--conf", path})
+	if err != nil {
+		t.Fatalf("Load: %s", err)
+	}
+	if len(cfg.Accounts) != 1 || cfg.Accounts[0].PersonNR != "acct-1" {
+		t.Fatalf("Accounts = %+v, want a single acct-1 entry", cfg.Accounts)
+	}
+}
+
+func TestLoadDoesntRequireSNSTopicForOtherBackends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	toml := `
+[credentials]
+personnr = "file-personnr"
+password = "file-password"
+
+[notify]
+backends = ["slack"]
+
+[notify.slack]
+webhook_url = "https://hooks.slack.example/x"
+`
+	if err := ioutil.WriteFile(path, []byte(toml), 0o600); err != nil {
+		t.Fatalf("couldn't write test config file. %s", err)
+	}
+
+	if _, err := Load([]string{"--conf", path}); err != nil {
+		t.Fatalf("Load: %s", err)
+	}
+}
@@ -0,0 +1,48 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Fanout sends an Event to every wrapped backend concurrently, aggregating any errors.
+type Fanout struct {
+	backends []Notifier
+}
+
+// NewFanout creates a Fanout that notifies every one of backends.
+// Returns *Fanout.
+func NewFanout(backends ...Notifier) *Fanout {
+	return &Fanout{backends: backends}
+}
+
+// Notify implements Notifier.
+func (f *Fanout) Notify(ctx context.Context, event Event) error {
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		errs []string
+	)
+
+	for _, backend := range f.backends {
+		wg.Add(1)
+		go func(n Notifier) {
+			defer wg.Done()
+
+			if err := n.Notify(ctx, event); err != nil {
+				mu.Lock()
+				errs = append(errs, err.Error())
+				mu.Unlock()
+			}
+		}(backend)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return fmt.Errorf("couldn't notify %d of %d backends: %s", len(errs), len(f.backends), strings.Join(errs, "; "))
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// fakeNotifier is a Notifier stub that always returns err.
+type fakeNotifier struct {
+	err error
+}
+
+func (f *fakeNotifier) Notify(ctx context.Context, event Event) error { return f.err }
+
+func TestFanoutNotifiesEveryBackend(t *testing.T) {
+	a, b := &fakeNotifier{}, &fakeNotifier{}
+	f := NewFanout(a, b)
+
+	if err := f.Notify(context.Background(), Event{Title: "t"}); err != nil {
+		t.Fatalf("Notify returned an error with no failing backends: %s", err)
+	}
+}
+
+func TestFanoutAggregatesErrors(t *testing.T) {
+	ok := &fakeNotifier{}
+	failA := &fakeNotifier{err: fmt.Errorf("backend a is down")}
+	failB := &fakeNotifier{err: fmt.Errorf("backend b is down")}
+
+	f := NewFanout(ok, failA, failB)
+
+	err := f.Notify(context.Background(), Event{Title: "t"})
+	if err == nil {
+		t.Fatal("Notify didn't return an error with two failing backends")
+	}
+	if !strings.Contains(err.Error(), "2 of 3") {
+		t.Errorf("error %q doesn't report 2 of 3 backends failing", err.Error())
+	}
+	if !strings.Contains(err.Error(), "backend a is down") || !strings.Contains(err.Error(), "backend b is down") {
+		t.Errorf("error %q doesn't include both underlying errors", err.Error())
+	}
+}
@@ -0,0 +1,27 @@
+// Package notify abstracts sending a message about a newly found förråd.
+package notify
+
+import "context"
+
+// Event describes a notification to send, decoupled from the backend that sends it.
+type Event struct {
+	Title string
+	Body  string
+	URL   string
+
+	// Forrad optionally carries the structured listings the notification is about.
+	Forrad []Forrad
+}
+
+// Forrad is a single matched förråd listing included in an Event.
+type Forrad struct {
+	Address string
+	SizeM2  float64
+	Rent    int
+	URL     string
+}
+
+// Notifier sends an Event to whatever backend it wraps.
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
@@ -0,0 +1,50 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Slack sends notifications to a Slack incoming webhook.
+type Slack struct {
+	webhookURL string
+	http       *http.Client
+}
+
+// NewSlack creates a Slack notifier that posts to webhookURL.
+// Returns *Slack.
+func NewSlack(webhookURL string) *Slack {
+	return &Slack{webhookURL: webhookURL, http: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Notify implements Notifier.
+func (s *Slack) Notify(ctx context.Context, event Event) error {
+	payload, err := json.Marshal(struct {
+		Text string `json:"text"`
+	}{Text: fmt.Sprintf("*%s*\n%s", event.Title, event.Body)})
+	if err != nil {
+		return fmt.Errorf("couldn't marshal slack payload. %s", err.Error())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("couldn't create slack request. %s", err.Error())
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("couldn't send slack request. %s", err.Error())
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack webhook returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlackNotifyPostsExpectedPayload(t *testing.T) {
+	var gotBody struct {
+		Text string `json:"text"`
+	}
+	var gotContentType string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotContentType = r.Header.Get("Content-Type")
+		json.NewDecoder(r.Body).Decode(&gotBody)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	s := &Slack{webhookURL: srv.URL, http: &http.Client{Timeout: 5 * time.Second}}
+
+	if err := s.Notify(context.Background(), Event{Title: "Nytt förråd!", Body: "Sveavägen"}); err != nil {
+		t.Fatalf("Notify: %s", err)
+	}
+
+	if gotContentType != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", gotContentType)
+	}
+	if !strings.Contains(gotBody.Text, "Nytt förråd!") || !strings.Contains(gotBody.Text, "Sveavägen") {
+		t.Errorf("posted text %q doesn't contain the title and body", gotBody.Text)
+	}
+}
+
+func TestSlackNotifyReturnsErrorOnNonOKStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	s := &Slack{webhookURL: srv.URL, http: &http.Client{Timeout: 5 * time.Second}}
+
+	if err := s.Notify(context.Background(), Event{Title: "t", Body: "b"}); err == nil {
+		t.Fatal("Notify didn't return an error on a 500 response")
+	}
+}
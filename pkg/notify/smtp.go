@@ -0,0 +1,36 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/smtp"
+)
+
+// SMTP sends notifications as plain text email.
+type SMTP struct {
+	addr string
+	auth smtp.Auth
+	from string
+	to   []string
+
+	// sendMail defaults to smtp.SendMail, overridden in tests.
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewSMTP creates an SMTP notifier that sends mail via addr as from, to the recipients in to.
+// auth may be nil if the server doesn't require authentication.
+// Returns *SMTP.
+func NewSMTP(addr string, auth smtp.Auth, from string, to []string) *SMTP {
+	return &SMTP{addr: addr, auth: auth, from: from, to: to, sendMail: smtp.SendMail}
+}
+
+// Notify implements Notifier.
+func (s *SMTP) Notify(ctx context.Context, event Event) error {
+	msg := fmt.Sprintf("Subject: %s\r\n\r\n%s\r\n", event.Title, event.Body)
+
+	if err := s.sendMail(s.addr, s.auth, s.from, s.to, []byte(msg)); err != nil {
+		return fmt.Errorf("couldn't send email. %s", err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/smtp"
+	"strings"
+	"testing"
+)
+
+func TestSMTPNotifySendsExpectedMessage(t *testing.T) {
+	var gotAddr, gotFrom string
+	var gotTo []string
+	var gotMsg []byte
+
+	s := &SMTP{
+		addr: "smtp.example.com:587",
+		from: "scraper@example.com",
+		to:   []string{"me@example.com"},
+		sendMail: func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+			gotAddr, gotFrom, gotTo, gotMsg = addr, from, to, msg
+			return nil
+		},
+	}
+
+	if err := s.Notify(context.Background(), Event{Title: "Nytt förråd!", Body: "Sveavägen"}); err != nil {
+		t.Fatalf("Notify: %s", err)
+	}
+
+	if gotAddr != "smtp.example.com:587" {
+		t.Errorf("addr = %q, want smtp.example.com:587", gotAddr)
+	}
+	if gotFrom != "scraper@example.com" {
+		t.Errorf("from = %q, want scraper@example.com", gotFrom)
+	}
+	if len(gotTo) != 1 || gotTo[0] != "me@example.com" {
+		t.Errorf("to = %v, want [me@example.com]", gotTo)
+	}
+	if !strings.Contains(string(gotMsg), "Subject: Nytt förråd!") || !strings.Contains(string(gotMsg), "Sveavägen") {
+		t.Errorf("message %q doesn't contain the subject and body", gotMsg)
+	}
+}
+
+func TestSMTPNotifyReturnsErrorFromSendMail(t *testing.T) {
+	s := &SMTP{
+		sendMail: func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+			return fmt.Errorf("connection refused")
+		},
+	}
+
+	if err := s.Notify(context.Background(), Event{Title: "t", Body: "b"}); err == nil {
+		t.Fatal("Notify didn't return an error when sendMail failed")
+	}
+}
@@ -0,0 +1,132 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	"github.com/aws/smithy-go"
+)
+
+// retryableCodes are the SNS error codes worth retrying: throttling and internal
+// service trouble. Anything else (bad ARN, auth failure, validation error, ...)
+// won't succeed on a retry, so it's treated as terminal.
+var retryableCodes = map[string]bool{
+	"Throttling":               true,
+	"ThrottlingException":      true,
+	"ThrottledException":       true,
+	"RequestLimitExceeded":     true,
+	"TooManyRequestsException": true,
+	"InternalError":            true,
+	"InternalErrorException":   true,
+	"InternalFailure":          true,
+	"ServiceUnavailable":       true,
+}
+
+// Retryer decides whether an SNS publish error should be retried, and for how long to
+// wait before the next attempt.
+type Retryer interface {
+	MaxAttempts() int
+	IsErrorRetryable(err error) bool
+	RetryDelay(attempt int) time.Duration
+}
+
+// fixedRetryer retries throttling and internal-error responses, making up to
+// maxAttempts attempts in all and waiting delay between attempts. Any other error
+// is treated as terminal.
+type fixedRetryer struct {
+	maxAttempts int
+	delay       time.Duration
+}
+
+// MaxAttempts implements Retryer.
+func (r *fixedRetryer) MaxAttempts() int { return r.maxAttempts }
+
+// IsErrorRetryable implements Retryer. Only throttling and internal-error codes
+// (see retryableCodes) are retried; anything else, such as a bad ARN or an auth
+// failure, won't succeed on a retry.
+func (r *fixedRetryer) IsErrorRetryable(err error) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return retryableCodes[apiErr.ErrorCode()]
+}
+
+// RetryDelay implements Retryer.
+func (r *fixedRetryer) RetryDelay(attempt int) time.Duration { return r.delay }
+
+// SNS sends notifications by publishing to an SNS topic.
+type SNS struct {
+	topicARN string
+	retryer  Retryer
+
+	// publish defaults to calling the SNS client's Publish, overridden in tests.
+	publish func(ctx context.Context, subject, message, topicARN string) error
+}
+
+// SNSOption configures an SNS notifier constructed with NewSNS.
+type SNSOption func(*SNS)
+
+// WithTopicARN overrides the topic to publish to.
+func WithTopicARN(topicARN string) SNSOption {
+	return func(s *SNS) { s.topicARN = topicARN }
+}
+
+// WithCustomRetryer overrides the retry strategy used on publish errors.
+func WithCustomRetryer(retryer Retryer) SNSOption {
+	return func(s *SNS) { s.retryer = retryer }
+}
+
+// WithRetries sets a fixed-delay retryer that retries up to max times, waiting delay
+// between each attempt.
+func WithRetries(max int, delay time.Duration) SNSOption {
+	return func(s *SNS) { s.retryer = &fixedRetryer{maxAttempts: max + 1, delay: delay} }
+}
+
+// NewSNS creates an SNS notifier for topicARN using cfg, applying any opts.
+// Returns *SNS.
+func NewSNS(cfg aws.Config, topicARN string, opts ...SNSOption) *SNS {
+	client := sns.NewFromConfig(cfg)
+	s := &SNS{
+		topicARN: topicARN,
+		retryer:  &fixedRetryer{maxAttempts: 3, delay: time.Second},
+	}
+	s.publish = func(ctx context.Context, subject, message, topicARN string) error {
+		_, err := client.Publish(ctx, &sns.PublishInput{Subject: &subject, Message: &message, TopicArn: &topicARN})
+		return err
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Notify implements Notifier.
+func (s *SNS) Notify(ctx context.Context, event Event) error {
+	subject, message := event.Title, event.Body
+
+	var err error
+	for attempt := 0; attempt < s.retryer.MaxAttempts(); attempt++ {
+		err = s.publish(ctx, subject, message, s.topicARN)
+		if err == nil {
+			return nil
+		}
+		if !s.retryer.IsErrorRetryable(err) || attempt == s.retryer.MaxAttempts()-1 {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.retryer.RetryDelay(attempt)):
+		}
+	}
+
+	return fmt.Errorf("couldn't publish to sns. %s", err.Error())
+}
@@ -0,0 +1,93 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/smithy-go"
+)
+
+func TestFixedRetryerIsErrorRetryable(t *testing.T) {
+	r := &fixedRetryer{}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"throttling is retryable", &smithy.GenericAPIError{Code: "ThrottlingException"}, true},
+		{"internal error is retryable", &smithy.GenericAPIError{Code: "InternalError"}, true},
+		{"validation error is terminal", &smithy.GenericAPIError{Code: "ValidationException"}, false},
+		{"non-api error is terminal", fmt.Errorf("connection reset"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.IsErrorRetryable(tt.err); got != tt.want {
+				t.Errorf("IsErrorRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSNSNotifyRetriesRetryableErrorsThenSucceeds(t *testing.T) {
+	attempts := 0
+	s := &SNS{
+		topicARN: "arn:aws:sns:eu-north-1:123456789012:test",
+		retryer:  &fixedRetryer{maxAttempts: 3, delay: time.Millisecond},
+		publish: func(ctx context.Context, subject, message, topicARN string) error {
+			attempts++
+			if attempts < 3 {
+				return &smithy.GenericAPIError{Code: "Throttling"}
+			}
+			return nil
+		},
+	}
+
+	if err := s.Notify(context.Background(), Event{Title: "t", Body: "b"}); err != nil {
+		t.Fatalf("Notify: %s", err)
+	}
+	if attempts != 3 {
+		t.Errorf("attempts = %d, want 3", attempts)
+	}
+}
+
+func TestSNSNotifyDoesNotRetryTerminalErrors(t *testing.T) {
+	attempts := 0
+	s := &SNS{
+		topicARN: "arn:aws:sns:eu-north-1:123456789012:test",
+		retryer:  &fixedRetryer{maxAttempts: 3, delay: time.Millisecond},
+		publish: func(ctx context.Context, subject, message, topicARN string) error {
+			attempts++
+			return &smithy.GenericAPIError{Code: "InvalidParameter"}
+		},
+	}
+
+	if err := s.Notify(context.Background(), Event{Title: "t", Body: "b"}); err == nil {
+		t.Fatal("Notify didn't return an error")
+	}
+	if attempts != 1 {
+		t.Errorf("attempts = %d, want 1 (terminal error shouldn't be retried)", attempts)
+	}
+}
+
+func TestSNSNotifyDoesNotSleepAfterFinalAttempt(t *testing.T) {
+	s := &SNS{
+		topicARN: "arn:aws:sns:eu-north-1:123456789012:test",
+		retryer:  &fixedRetryer{maxAttempts: 3, delay: 200 * time.Millisecond},
+		publish: func(ctx context.Context, subject, message, topicARN string) error {
+			return &smithy.GenericAPIError{Code: "Throttling"}
+		},
+	}
+
+	start := time.Now()
+	if err := s.Notify(context.Background(), Event{Title: "t", Body: "b"}); err == nil {
+		t.Fatal("Notify didn't return an error")
+	}
+	// Two delays between three attempts, not three (no sleep after the final attempt).
+	if elapsed := time.Since(start); elapsed >= 600*time.Millisecond {
+		t.Errorf("Notify took %s, want well under 3x the retry delay", elapsed)
+	}
+}
@@ -0,0 +1,56 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// telegramAPIBase is the Telegram Bot API's base url, the default for
+// Telegram.apiBase (which tests override).
+const telegramAPIBase = "https://api.telegram.org"
+
+// Telegram sends notifications via the Telegram Bot API.
+type Telegram struct {
+	botToken string
+	chatID   string
+	http     *http.Client
+	apiBase  string
+}
+
+// NewTelegram creates a Telegram notifier that sends messages as botToken to chatID.
+// Returns *Telegram.
+func NewTelegram(botToken string, chatID string) *Telegram {
+	return &Telegram{botToken: botToken, chatID: chatID, http: &http.Client{Timeout: 10 * time.Second}, apiBase: telegramAPIBase}
+}
+
+// Notify implements Notifier.
+func (t *Telegram) Notify(ctx context.Context, event Event) error {
+	endpoint := fmt.Sprintf("%s/bot%s/sendMessage", t.apiBase, t.botToken)
+
+	payload := url.Values{
+		"chat_id": {t.chatID},
+		"text":    {fmt.Sprintf("%s\n\n%s", event.Title, event.Body)},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, strings.NewReader(payload.Encode()))
+	if err != nil {
+		return fmt.Errorf("couldn't create telegram request. %s", err.Error())
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := t.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("couldn't send telegram request. %s", err.Error())
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("telegram api returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTelegramNotifySendsExpectedMessage(t *testing.T) {
+	var gotPath string
+	var gotForm url.Values
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		r.ParseForm()
+		gotForm = r.Form
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	tg := &Telegram{botToken: "token123", chatID: "42", http: &http.Client{Timeout: 5 * time.Second}, apiBase: srv.URL}
+
+	if err := tg.Notify(context.Background(), Event{Title: "Nytt förråd!", Body: "Sveavägen"}); err != nil {
+		t.Fatalf("Notify: %s", err)
+	}
+
+	if gotPath != "/bottoken123/sendMessage" {
+		t.Errorf("path = %q, want /bottoken123/sendMessage", gotPath)
+	}
+	if gotForm.Get("chat_id") != "42" {
+		t.Errorf("chat_id = %q, want 42", gotForm.Get("chat_id"))
+	}
+	if !strings.Contains(gotForm.Get("text"), "Nytt förråd!") || !strings.Contains(gotForm.Get("text"), "Sveavägen") {
+		t.Errorf("text %q doesn't contain the title and body", gotForm.Get("text"))
+	}
+}
+
+func TestTelegramNotifyReturnsErrorOnNonOKStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	tg := &Telegram{botToken: "token123", chatID: "42", http: &http.Client{Timeout: 5 * time.Second}, apiBase: srv.URL}
+
+	if err := tg.Notify(context.Background(), Event{Title: "t", Body: "b"}); err == nil {
+		t.Fatal("Notify didn't return an error on a 403 response")
+	}
+}
@@ -0,0 +1,30 @@
+package scraper
+
+import "fmt"
+
+// Login will log c in against stockholmshem.
+// Returns error.
+func (c *Client) Login() error {
+	// Create payload.
+	payload := c.createLoginPayload()
+
+	// Create the request.
+	req, err := c.createHTTPRequest("POST", "https://www.stockholmshem.se/logga-in/?returnUrl=/mina-sidor/smaforrad/", payload)
+	if err != nil {
+		return err
+	}
+
+	// Send the request.
+	_, err = c.sendHTTPRequest(req, 302)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// createLoginPayload returns the payload used to log in.
+// Returns []byte.
+func (c *Client) createLoginPayload() []byte {
+	return []byte(fmt.Sprintf("Username=%s&Password=%s", c.account.PersonNR, c.account.Password))
+}
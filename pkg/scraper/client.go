@@ -0,0 +1,125 @@
+// Package scraper knows how to log in to and scrape stockholmshem.se for förråd listings.
+package scraper
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+
+	"github.com/nuttmeister/sthlmshem-sok-forrad/pkg/config"
+)
+
+// Client scrapes stockholmshem.se for förråd on behalf of a single account, keeping
+// its authenticated session in an http.Client.
+type Client struct {
+	http    *http.Client
+	headers map[string]string
+	cfg     *config.Config
+	account config.Credentials
+	store   SessionStore
+
+	// seen holds the keys (see Forrad.key) of listings already notified about. It's
+	// replaced wholesale on every NewListings call given a non-empty listing set, so
+	// rented-out förråd age out instead of growing this set forever.
+	seen map[string]struct{}
+}
+
+// New creates a new Client that logs in as account. awsCfg is used to construct a
+// SessionStore if cfg.SessionStore.Kind is set.
+// Returns *Client and error.
+func New(cfg *config.Config, account config.Credentials, awsCfg aws.Config) (*Client, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create cookie jar. %s", err.Error())
+	}
+
+	store, err := newSessionStore(cfg, account, awsCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		http: &http.Client{
+			Jar:     jar,
+			Timeout: time.Duration(cfg.Scraper.TimeoutMS) * time.Millisecond,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		headers: map[string]string{
+			"User-Agent":   cfg.Scraper.UserAgent,
+			"Accept":       "*/*",
+			"Content-Type": "application/x-www-form-urlencoded",
+		},
+		cfg:     cfg,
+		account: account,
+		store:   store,
+		seen:    make(map[string]struct{}),
+	}, nil
+}
+
+// createHTTPRequest will create a request using method, url and payload and set headers based on c.headers.
+// Returns *http.Request and error.
+func (c *Client) createHTTPRequest(method string, url string, payload []byte) (*http.Request, error) {
+	// Replace any {epoch} with now.unix * 1000.
+	now := time.Now().Unix() * 1000
+	url = strings.ReplaceAll(url, "{epoch}", fmt.Sprintf("%d", now))
+
+	req, err := http.NewRequest(method, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create request for %s %s. %s", method, url, err.Error())
+	}
+
+	// Set headers.
+	for key, val := range c.headers {
+		req.Header.Set(key, val)
+	}
+
+	return req, nil
+}
+
+// sendHTTPRequest will send req and save any cookies to c's jar and return the body.
+// If the response status code doesn't match statusCode a *statusCodeError is returned along with the body.
+// Returns []byte and error.
+func (c *Client) sendHTTPRequest(req *http.Request, statusCode int) ([]byte, error) {
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't send http request. %s", err.Error())
+	}
+	defer resp.Body.Close()
+
+	// Read the body.
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read body of response. %s", err.Error())
+	}
+
+	// Check that statuscode matches what we expect.
+	// But also return the body.
+	if resp.StatusCode != statusCode {
+		return body, &statusCodeError{want: statusCode, got: resp.StatusCode, url: resp.Request.URL.String()}
+	}
+
+	// Save all cookies if response is successfull.
+	c.http.Jar.SetCookies(resp.Request.URL, resp.Cookies())
+	return body, nil
+}
+
+// statusCodeError is returned by sendHTTPRequest when the response status code
+// doesn't match what was expected.
+type statusCodeError struct {
+	want int
+	got  int
+	url  string
+}
+
+// Error implements error.
+func (e *statusCodeError) Error() string {
+	return fmt.Sprintf("status code missmatch. wanted %d got %d for %s", e.want, e.got, e.url)
+}
@@ -0,0 +1,156 @@
+package scraper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// ErrNotLoggedIn is returned by Forrad when the widgets endpoint redirects to
+// the login page instead of returning data, meaning the session has expired.
+var ErrNotLoggedIn = fmt.Errorf("not logged in")
+
+// Forrad is a single förråd listing parsed out of the widgets response.
+type Forrad struct {
+	Address string
+	SizeM2  float64
+	Rent    int
+	URL     string
+
+	// sizeKnown is false when the listing's markup didn't match sizeRe, so
+	// SizeM2 stayed at its zero value rather than a genuinely parsed size.
+	sizeKnown bool
+}
+
+// key uniquely identifies a listing so it can be diffed against what's already been seen.
+func (f Forrad) key() string {
+	if f.URL != "" {
+		return f.URL
+	}
+	return fmt.Sprintf("%s|%d", f.Address, f.Rent)
+}
+
+// jsonpWrapper strips a JSONP response's callback wrapper, e.g. jQuery123_456(<json>);
+// (?s) makes . match newlines too, in case the response is ever pretty-printed.
+var jsonpWrapper = regexp.MustCompile(`(?s)^[^(]*\((.*)\);?\s*$`)
+
+// widgetsResponse is the decoded JSONP payload, keyed by widget name.
+type widgetsResponse map[string]struct {
+	HTML string `json:"html"`
+}
+
+// Forrad fetches the widgets endpoint and returns the listings that pass the
+// configured filters (scraper.min_size_m2, scraper.max_rent, scraper.area_regex).
+// Returns ErrNotLoggedIn if c's session isn't (or no longer is) authenticated.
+// Returns []Forrad and error.
+func (c *Client) Forrad() ([]Forrad, error) {
+	// Create the request.
+	req, err := c.createHTTPRequest("GET", c.cfg.Scraper.Widgets, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Send the request. A login redirect means the session isn't valid (any longer).
+	body, err := c.sendHTTPRequest(req, 200)
+	if err != nil {
+		if isLoginRedirect(err) {
+			return nil, ErrNotLoggedIn
+		}
+		return nil, err
+	}
+
+	listings, err := parseWidgetsResponse(body)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.filterListings(listings)
+}
+
+// parseWidgetsResponse strips the JSONP callback wrapper, decodes the inner JSON and
+// extracts the listings out of the "objektlista@forrad" widget's HTML fragment.
+// Returns []Forrad and error.
+func parseWidgetsResponse(body []byte) ([]Forrad, error) {
+	match := jsonpWrapper.FindSubmatch(body)
+	if match == nil {
+		return nil, fmt.Errorf("couldn't find jsonp payload in widgets response")
+	}
+
+	var widgets widgetsResponse
+	if err := json.Unmarshal(match[1], &widgets); err != nil {
+		return nil, fmt.Errorf("couldn't decode widgets response. %s", err.Error())
+	}
+
+	widget, ok := widgets["objektlista@forrad"]
+	if !ok {
+		return nil, nil
+	}
+
+	return parseListings(widget.HTML)
+}
+
+// filterListings drops listings that don't pass the configured filters.
+// Returns []Forrad and error.
+func (c *Client) filterListings(listings []Forrad) ([]Forrad, error) {
+	var areaRe *regexp.Regexp
+	if c.cfg.Scraper.AreaRegex != "" {
+		var err error
+		areaRe, err = regexp.Compile(c.cfg.Scraper.AreaRegex)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't compile scraper.area_regex. %s", err.Error())
+		}
+	}
+
+	filtered := make([]Forrad, 0, len(listings))
+	for _, l := range listings {
+		if c.cfg.Scraper.MinSizeM2 > 0 && l.sizeKnown && l.SizeM2 < c.cfg.Scraper.MinSizeM2 {
+			continue
+		}
+		if c.cfg.Scraper.MaxRent > 0 && l.Rent > c.cfg.Scraper.MaxRent {
+			continue
+		}
+		if areaRe != nil && !areaRe.MatchString(l.Address) {
+			continue
+		}
+		filtered = append(filtered, l)
+	}
+
+	return filtered, nil
+}
+
+// NewListings returns the subset of listings not already marked as seen (via a previous
+// call to NewListings, possibly restored from the session store). As long as listings is
+// non-empty, seen is also replaced with exactly listings' keys, so förråd that have since
+// been rented out age out instead of keeping seen growing forever. An empty listings is
+// left untouched instead of wiping seen, since it's as likely to be a transient scrape
+// hiccup (see parseWidgetsResponse) as genuinely zero förråd currently listed.
+// Returns []Forrad.
+func (c *Client) NewListings(listings []Forrad) []Forrad {
+	new := make([]Forrad, 0)
+	if len(listings) == 0 {
+		return new
+	}
+
+	seen := make(map[string]struct{}, len(listings))
+	for _, l := range listings {
+		seen[l.key()] = struct{}{}
+		if _, ok := c.seen[l.key()]; ok {
+			continue
+		}
+		new = append(new, l)
+	}
+	c.seen = seen
+
+	return new
+}
+
+// isLoginRedirect reports whether err was returned because the widgets endpoint
+// answered with a redirect (to the login page) instead of the expected status code.
+func isLoginRedirect(err error) bool {
+	var statusErr *statusCodeError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	return statusErr.got >= 300 && statusErr.got < 400
+}
@@ -0,0 +1,116 @@
+package scraper
+
+import (
+	"testing"
+
+	"github.com/nuttmeister/sthlmshem-sok-forrad/pkg/config"
+)
+
+func TestParseWidgetsResponse(t *testing.T) {
+	body := []byte(`jQuery123_456({"objektlista@forrad":{"html":"` +
+		`<div class=\"objekt\"><a href=\"/forrad/1\">Sveavägen</a><span>12,5 m2, 350 kr/mån</span></div>` +
+		`<div class=\"objekt\"><a href=\"/forrad/2\">Götgatan</a><span>4 m2, 150 kr/mån</span></div>` +
+		`"}});`)
+
+	listings, err := parseWidgetsResponse(body)
+	if err != nil {
+		t.Fatalf("parseWidgetsResponse: %s", err)
+	}
+	if len(listings) != 2 {
+		t.Fatalf("got %d listings, want 2", len(listings))
+	}
+
+	first := listings[0]
+	if first.Address != "Sveavägen" {
+		t.Errorf("listings[0].Address = %q, want %q", first.Address, "Sveavägen")
+	}
+	if first.URL != "https://www.stockholmshem.se/forrad/1" {
+		t.Errorf("listings[0].URL = %q, want the resolved absolute url", first.URL)
+	}
+	if first.SizeM2 != 12.5 {
+		t.Errorf("listings[0].SizeM2 = %v, want 12.5", first.SizeM2)
+	}
+	if first.Rent != 350 {
+		t.Errorf("listings[0].Rent = %d, want 350", first.Rent)
+	}
+}
+
+func TestParseWidgetsResponseMissingWidget(t *testing.T) {
+	body := []byte(`jQuery123_456({"alert":{"html":"no förråd here"}});`)
+
+	listings, err := parseWidgetsResponse(body)
+	if err != nil {
+		t.Fatalf("parseWidgetsResponse: %s", err)
+	}
+	if listings != nil {
+		t.Errorf("got %v, want nil when the förråd widget is missing", listings)
+	}
+}
+
+func TestParseWidgetsResponseNotJSONP(t *testing.T) {
+	if _, err := parseWidgetsResponse([]byte("not jsonp at all")); err == nil {
+		t.Fatal("parseWidgetsResponse didn't error on a non-jsonp body")
+	}
+}
+
+func TestFilterListingsBySizeAndRent(t *testing.T) {
+	c := &Client{cfg: &config.Config{Scraper: config.Scraper{MinSizeM2: 5, MaxRent: 300}}}
+
+	listings := []Forrad{
+		{Address: "too small", SizeM2: 4, sizeKnown: true, Rent: 200},
+		{Address: "too expensive", SizeM2: 10, sizeKnown: true, Rent: 400},
+		{Address: "just right", SizeM2: 10, sizeKnown: true, Rent: 200},
+		{Address: "unknown size kept", Rent: 200},
+	}
+
+	filtered, err := c.filterListings(listings)
+	if err != nil {
+		t.Fatalf("filterListings: %s", err)
+	}
+
+	var addresses []string
+	for _, f := range filtered {
+		addresses = append(addresses, f.Address)
+	}
+	want := []string{"just right", "unknown size kept"}
+	if len(addresses) != len(want) || addresses[0] != want[0] || addresses[1] != want[1] {
+		t.Errorf("filterListings = %v, want %v", addresses, want)
+	}
+}
+
+func TestNewListingsAgesOutRentedListings(t *testing.T) {
+	c := &Client{seen: map[string]struct{}{"https://example.com/1": {}, "https://example.com/2": {}}}
+
+	// "/1" is no longer listed (rented out), "/3" is new.
+	listings := []Forrad{
+		{URL: "https://example.com/2"},
+		{URL: "https://example.com/3"},
+	}
+
+	new := c.NewListings(listings)
+	if len(new) != 1 || new[0].URL != "https://example.com/3" {
+		t.Errorf("NewListings = %v, want only /3", new)
+	}
+
+	if _, ok := c.seen["https://example.com/1"]; ok {
+		t.Error("seen still holds /1, which is no longer listed")
+	}
+	if _, ok := c.seen["https://example.com/2"]; !ok {
+		t.Error("seen dropped /2, which is still listed")
+	}
+	if _, ok := c.seen["https://example.com/3"]; !ok {
+		t.Error("seen didn't pick up the newly returned /3")
+	}
+}
+
+func TestNewListingsLeavesSeenAloneOnEmptyResult(t *testing.T) {
+	c := &Client{seen: map[string]struct{}{"https://example.com/1": {}}}
+
+	new := c.NewListings(nil)
+	if len(new) != 0 {
+		t.Errorf("NewListings(nil) = %v, want none", new)
+	}
+	if _, ok := c.seen["https://example.com/1"]; !ok {
+		t.Error("seen was wiped by an empty (possibly transient) listings result")
+	}
+}
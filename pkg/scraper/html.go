@@ -0,0 +1,147 @@
+package scraper
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// listingBaseURL is prepended to relative detail urls found in the widgets markup.
+const listingBaseURL = "https://www.stockholmshem.se"
+
+var (
+	sizeRe = regexp.MustCompile(`(\d+(?:[.,]\d+)?)\s*m`)
+	rentRe = regexp.MustCompile(`(\d+)\s*kr`)
+)
+
+// parseListings walks fragment (the "objektlista@forrad" widget's HTML) and extracts one
+// Forrad per listing. Markup it doesn't recognize is skipped rather than erroring, since
+// the exact structure stockholmshem.se renders can change without notice.
+// Returns []Forrad and error.
+func parseListings(fragment string) ([]Forrad, error) {
+	nodes, err := html.ParseFragment(strings.NewReader(fragment), &html.Node{Type: html.ElementNode, Data: "div", DataAtom: atom.Div})
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse listings html. %s", err.Error())
+	}
+
+	listings := make([]Forrad, 0)
+	for _, n := range nodes {
+		for _, container := range findElements(n, func(n *html.Node) bool { return hasClass(n, "objekt") }) {
+			listings = append(listings, parseListing(container))
+		}
+	}
+
+	return listings, nil
+}
+
+// parseListing extracts a Forrad from a single listing container.
+// Returns Forrad.
+func parseListing(container *html.Node) Forrad {
+	f := Forrad{}
+
+	if a, ok := findElement(container, func(n *html.Node) bool { return n.DataAtom == atom.A }); ok {
+		if href, ok := attrOf(a, "href"); ok {
+			f.URL = resolveURL(href)
+		}
+		f.Address = strings.TrimSpace(textOf(a))
+	}
+
+	text := textOf(container)
+	if m := sizeRe.FindStringSubmatch(text); m != nil {
+		if size, err := strconv.ParseFloat(strings.ReplaceAll(m[1], ",", "."), 64); err == nil {
+			f.SizeM2 = size
+			f.sizeKnown = true
+		}
+	}
+	if m := rentRe.FindStringSubmatch(text); m != nil {
+		f.Rent, _ = strconv.Atoi(m[1])
+	}
+
+	if f.Address == "" {
+		f.Address = strings.TrimSpace(text)
+	}
+
+	return f
+}
+
+// resolveURL turns a relative href into an absolute stockholmshem.se url.
+func resolveURL(href string) string {
+	if strings.HasPrefix(href, "http") {
+		return href
+	}
+	return listingBaseURL + "/" + strings.TrimPrefix(href, "/")
+}
+
+// hasClass reports whether n has class among its space separated "class" attribute values.
+func hasClass(n *html.Node, class string) bool {
+	for _, a := range n.Attr {
+		if a.Key != "class" {
+			continue
+		}
+		for _, c := range strings.Fields(a.Val) {
+			if c == class {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// attrOf returns the value of n's key attribute, if set.
+func attrOf(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+// textOf recursively concatenates all text nodes under n.
+func textOf(n *html.Node) string {
+	var sb strings.Builder
+
+	var rec func(*html.Node)
+	rec = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			rec(c)
+		}
+	}
+	rec(n)
+
+	return sb.String()
+}
+
+// findElements returns every element under (and including) n for which pred returns true.
+func findElements(n *html.Node, pred func(*html.Node) bool) []*html.Node {
+	var found []*html.Node
+
+	var rec func(*html.Node)
+	rec = func(n *html.Node) {
+		if n.Type == html.ElementNode && pred(n) {
+			found = append(found, n)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			rec(c)
+		}
+	}
+	rec(n)
+
+	return found
+}
+
+// findElement returns the first element under (and including) n for which pred returns true.
+func findElement(n *html.Node, pred func(*html.Node) bool) (*html.Node, bool) {
+	found := findElements(n, pred)
+	if len(found) == 0 {
+		return nil, false
+	}
+	return found[0], true
+}
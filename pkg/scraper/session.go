@@ -0,0 +1,436 @@
+package scraper
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/nuttmeister/sthlmshem-sok-forrad/pkg/config"
+)
+
+// sessionURL is the url the jar's cookies are stored and restored against.
+const sessionURL = "https://www.stockholmshem.se/"
+
+// fernetTTL is how old a stored session token is allowed to be before it's
+// rejected and a fresh login is forced.
+const fernetTTL = 30 * time.Minute
+
+// SessionStore persists and restores an authenticated cookie jar, together with the
+// set of förråd listings already seen, between Lambda invocations.
+type SessionStore interface {
+	// Load returns the previously stored jar and seen set, or a nil jar if none exists yet.
+	Load(ctx context.Context) (*cookiejar.Jar, []string, error)
+
+	// Save persists jar and seen for the next invocation.
+	Save(ctx context.Context, jar *cookiejar.Jar, seen []string) error
+}
+
+// Restore tries to load a previously persisted session into c. restored is
+// true only if a valid, unexpired session was found and loaded.
+// Returns bool and error.
+func (c *Client) Restore(ctx context.Context) (bool, error) {
+	if c.store == nil {
+		return false, nil
+	}
+
+	jar, seen, err := c.store.Load(ctx)
+	if err != nil || jar == nil {
+		return false, nil
+	}
+
+	c.http.Jar = jar
+	for _, key := range seen {
+		c.seen[key] = struct{}{}
+	}
+
+	return true, nil
+}
+
+// Persist saves c's current session and seen listings so they can be restored on the
+// next invocation. It's a no-op if no SessionStore is configured.
+// Returns error.
+func (c *Client) Persist(ctx context.Context) error {
+	if c.store == nil {
+		return nil
+	}
+
+	jar, ok := c.http.Jar.(*cookiejar.Jar)
+	if !ok {
+		return fmt.Errorf("client jar isn't a *cookiejar.Jar")
+	}
+
+	seen := make([]string, 0, len(c.seen))
+	for key := range c.seen {
+		seen = append(seen, key)
+	}
+
+	return c.store.Save(ctx, jar, seen)
+}
+
+// sessionState is the serializable form of what's persisted between invocations.
+type sessionState struct {
+	Cookies []*http.Cookie
+	Seen    []string
+}
+
+// stateToBytes gob-encodes the cookies jar holds for sessionURL, together with seen.
+// Returns []byte and error.
+func stateToBytes(jar *cookiejar.Jar, seen []string) ([]byte, error) {
+	u, err := url.Parse(sessionURL)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't parse session url. %s", err.Error())
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(sessionState{Cookies: jar.Cookies(u), Seen: seen}); err != nil {
+		return nil, fmt.Errorf("couldn't encode session state. %s", err.Error())
+	}
+
+	return buf.Bytes(), nil
+}
+
+// bytesToState decodes b into a cookie jar set against sessionURL, together with the seen set.
+// Returns *cookiejar.Jar, []string and error.
+func bytesToState(b []byte) (*cookiejar.Jar, []string, error) {
+	var state sessionState
+	if err := gob.NewDecoder(bytes.NewReader(b)).Decode(&state); err != nil {
+		return nil, nil, fmt.Errorf("couldn't decode session state. %s", err.Error())
+	}
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("couldn't create cookie jar. %s", err.Error())
+	}
+
+	u, err := url.Parse(sessionURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("couldn't parse session url. %s", err.Error())
+	}
+
+	jar.SetCookies(u, state.Cookies)
+	return jar, state.Seen, nil
+}
+
+// fernetKey splits a 32 byte key into the signing and encryption halves,
+// mirroring the fernet spec.
+type fernetKey struct {
+	signing    [16]byte
+	encryption [16]byte
+}
+
+// newFernetKey builds a fernetKey from the hex encoded 32 byte key in keyHex.
+// Returns fernetKey and error.
+func newFernetKey(keyHex string) (fernetKey, error) {
+	if keyHex == "" {
+		return fernetKey{}, fmt.Errorf("no session encryption key configured")
+	}
+
+	key, err := hex.DecodeString(keyHex)
+	if err != nil {
+		return fernetKey{}, fmt.Errorf("couldn't hex decode session encryption key. %s", err.Error())
+	}
+	if len(key) != 32 {
+		return fernetKey{}, fmt.Errorf("session encryption key must be 32 bytes, got %d", len(key))
+	}
+
+	var fk fernetKey
+	copy(fk.signing[:], key[:16])
+	copy(fk.encryption[:], key[16:])
+	return fk, nil
+}
+
+// encryptSession encrypts plaintext into a fernet-style token: a version byte,
+// an 8 byte big endian unix timestamp, a 16 byte IV, the AES-CBC ciphertext
+// and a trailing HMAC-SHA256 over everything that came before it.
+// Returns []byte and error.
+func encryptSession(fk fernetKey, plaintext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(fk.encryption[:])
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create aes cipher. %s", err.Error())
+	}
+
+	padded := pkcs7Pad(plaintext, block.BlockSize())
+
+	iv := make([]byte, block.BlockSize())
+	if _, err := rand.Read(iv); err != nil {
+		return nil, fmt.Errorf("couldn't generate iv. %s", err.Error())
+	}
+
+	ciphertext := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, padded)
+
+	msg := make([]byte, 0, 9+len(iv)+len(ciphertext))
+	msg = append(msg, 0x80)
+	ts := make([]byte, 8)
+	binary.BigEndian.PutUint64(ts, uint64(time.Now().Unix()))
+	msg = append(msg, ts...)
+	msg = append(msg, iv...)
+	msg = append(msg, ciphertext...)
+
+	mac := hmac.New(sha256.New, fk.signing[:])
+	mac.Write(msg)
+
+	return append(msg, mac.Sum(nil)...), nil
+}
+
+// decryptSession reverses encryptSession, rejecting the token if its HMAC
+// doesn't match or if it's older than fernetTTL.
+// Returns []byte and error.
+func decryptSession(fk fernetKey, token []byte) ([]byte, error) {
+	if len(token) < 1+8+16+sha256.Size {
+		return nil, fmt.Errorf("session token too short")
+	}
+
+	msg, sig := token[:len(token)-sha256.Size], token[len(token)-sha256.Size:]
+
+	mac := hmac.New(sha256.New, fk.signing[:])
+	mac.Write(msg)
+	if !hmac.Equal(mac.Sum(nil), sig) {
+		return nil, fmt.Errorf("session token has an invalid signature")
+	}
+
+	if msg[0] != 0x80 {
+		return nil, fmt.Errorf("session token has an unknown version %d", msg[0])
+	}
+
+	ts := time.Unix(int64(binary.BigEndian.Uint64(msg[1:9])), 0)
+	if time.Since(ts) > fernetTTL {
+		return nil, fmt.Errorf("session token is older than %s", fernetTTL)
+	}
+
+	block, err := aes.NewCipher(fk.encryption[:])
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create aes cipher. %s", err.Error())
+	}
+
+	iv, ciphertext := msg[9:9+block.BlockSize()], msg[9+block.BlockSize():]
+	if len(ciphertext) == 0 || len(ciphertext)%block.BlockSize() != 0 {
+		return nil, fmt.Errorf("session token ciphertext isn't block aligned")
+	}
+
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext)
+
+	return pkcs7Unpad(plaintext)
+}
+
+// pkcs7Pad pads b to a multiple of size using PKCS#7.
+func pkcs7Pad(b []byte, size int) []byte {
+	pad := size - len(b)%size
+	return append(b, bytes.Repeat([]byte{byte(pad)}, pad)...)
+}
+
+// pkcs7Unpad removes PKCS#7 padding from b.
+// Returns []byte and error.
+func pkcs7Unpad(b []byte) ([]byte, error) {
+	if len(b) == 0 {
+		return nil, fmt.Errorf("can't unpad empty data")
+	}
+
+	pad := int(b[len(b)-1])
+	if pad == 0 || pad > len(b) {
+		return nil, fmt.Errorf("invalid padding")
+	}
+	if subtle.ConstantTimeCompare(b[len(b)-pad:], bytes.Repeat([]byte{byte(pad)}, pad)) != 1 {
+		return nil, fmt.Errorf("invalid padding")
+	}
+
+	return b[:len(b)-pad], nil
+}
+
+// s3SessionStore stores the encrypted session token as a single object in S3.
+type s3SessionStore struct {
+	client *s3.Client
+	bucket string
+	key    string
+	keyHex string
+}
+
+// Load implements SessionStore.
+func (s *s3SessionStore) Load(ctx context.Context) (*cookiejar.Jar, []string, error) {
+	fk, err := newFernetKey(s.keyHex)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key),
+	})
+	if err != nil {
+		return nil, nil, nil
+	}
+	defer resp.Body.Close()
+
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(resp.Body); err != nil {
+		return nil, nil, fmt.Errorf("couldn't read session object. %s", err.Error())
+	}
+
+	plaintext, err := decryptSession(fk, buf.Bytes())
+	if err != nil {
+		return nil, nil, nil
+	}
+
+	return bytesToState(plaintext)
+}
+
+// Save implements SessionStore.
+func (s *s3SessionStore) Save(ctx context.Context, jar *cookiejar.Jar, seen []string) error {
+	fk, err := newFernetKey(s.keyHex)
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := stateToBytes(jar, seen)
+	if err != nil {
+		return err
+	}
+
+	token, err := encryptSession(fk, plaintext)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key),
+		Body:   bytes.NewReader(token),
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't put session object. %s", err.Error())
+	}
+
+	return nil
+}
+
+// dynamoDBSessionStore stores the encrypted session token as a single item in a DynamoDB table.
+type dynamoDBSessionStore struct {
+	client *dynamodb.Client
+	table  string
+	id     string
+	keyHex string
+}
+
+// dynamoSessionItem is the shape of the item stored in DynamoDB.
+type dynamoSessionItem struct {
+	ID    string `dynamodbav:"id"`
+	Token []byte `dynamodbav:"token"`
+}
+
+// Load implements SessionStore.
+func (d *dynamoDBSessionStore) Load(ctx context.Context) (*cookiejar.Jar, []string, error) {
+	fk, err := newFernetKey(d.keyHex)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key, err := attributevalue.MarshalMap(struct {
+		ID string `dynamodbav:"id"`
+	}{ID: d.id})
+	if err != nil {
+		return nil, nil, fmt.Errorf("couldn't marshal session key. %s", err.Error())
+	}
+
+	resp, err := d.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(d.table),
+		Key:       key,
+	})
+	if err != nil || len(resp.Item) == 0 {
+		return nil, nil, nil
+	}
+
+	var item dynamoSessionItem
+	if err := attributevalue.UnmarshalMap(resp.Item, &item); err != nil {
+		return nil, nil, fmt.Errorf("couldn't unmarshal session item. %s", err.Error())
+	}
+
+	plaintext, err := decryptSession(fk, item.Token)
+	if err != nil {
+		return nil, nil, nil
+	}
+
+	return bytesToState(plaintext)
+}
+
+// Save implements SessionStore.
+func (d *dynamoDBSessionStore) Save(ctx context.Context, jar *cookiejar.Jar, seen []string) error {
+	fk, err := newFernetKey(d.keyHex)
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := stateToBytes(jar, seen)
+	if err != nil {
+		return err
+	}
+
+	token, err := encryptSession(fk, plaintext)
+	if err != nil {
+		return err
+	}
+
+	item, err := attributevalue.MarshalMap(dynamoSessionItem{ID: d.id, Token: token})
+	if err != nil {
+		return fmt.Errorf("couldn't marshal session item. %s", err.Error())
+	}
+
+	_, err = d.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(d.table),
+		Item:      item,
+	})
+	if err != nil {
+		return fmt.Errorf("couldn't put session item. %s", err.Error())
+	}
+
+	return nil
+}
+
+// newSessionStore builds a SessionStore based on cfg.SessionStore.Kind ("s3" or "dynamodb"),
+// scoped to account so several accounts in the same deployment don't share a session.
+// Returns nil, nil if cfg.SessionStore.Kind isn't set, meaning session persistence is disabled.
+// Returns SessionStore and error.
+func newSessionStore(cfg *config.Config, account config.Credentials, awsCfg aws.Config) (SessionStore, error) {
+	switch cfg.SessionStore.Kind {
+	case "":
+		return nil, nil
+
+	case "s3":
+		if cfg.SessionStore.Bucket == "" {
+			return nil, fmt.Errorf("SESSION_STORE_BUCKET must be set when SESSION_STORE is s3")
+		}
+		key := cfg.SessionStore.Key
+		if key == "" {
+			key = "session"
+		}
+		key = fmt.Sprintf("%s-%s.token", key, account.PersonNR)
+		return &s3SessionStore{client: s3.NewFromConfig(awsCfg), bucket: cfg.SessionStore.Bucket, key: key, keyHex: cfg.SessionStore.EncryptionKeyHex}, nil
+
+	case "dynamodb":
+		if cfg.SessionStore.Table == "" {
+			return nil, fmt.Errorf("SESSION_STORE_TABLE must be set when SESSION_STORE is dynamodb")
+		}
+		return &dynamoDBSessionStore{client: dynamodb.NewFromConfig(awsCfg), table: cfg.SessionStore.Table, id: "session-" + account.PersonNR, keyHex: cfg.SessionStore.EncryptionKeyHex}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown SESSION_STORE %q", cfg.SessionStore.Kind)
+	}
+}